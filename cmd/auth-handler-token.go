@@ -0,0 +1,352 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"gopkg.in/square/go-jose.v2/jwk"
+)
+
+// cannedPolicyContextKey is the context key under which the canned policy
+// name resolved from a bearer token or X.509 certificate is stored.
+type cannedPolicyContextKey struct{}
+
+// contextWithCannedPolicy returns a copy of ctx carrying the resolved
+// canned policy name, readable downstream of setTokenAuthHandler with
+// cannedPolicyFromContext.
+func contextWithCannedPolicy(ctx context.Context, policyName string) context.Context {
+	return context.WithValue(ctx, cannedPolicyContextKey{}, policyName)
+}
+
+// cannedPolicyFromContext returns the canned policy name stashed by
+// setTokenAuthHandler, if any.
+func cannedPolicyFromContext(ctx context.Context) (string, bool) {
+	policyName, ok := ctx.Value(cannedPolicyContextKey{}).(string)
+	return policyName, ok
+}
+
+// oidcProvider describes a single federated identity issuer that bearer
+// tokens can be verified against. Deployments that federate across sites
+// (e.g. WLCG/EGI style research infrastructures) typically configure one
+// entry per identity provider.
+type oidcProvider struct {
+	Enabled bool `json:"enable"`
+
+	// IssuerURL is matched against the token's "iss" claim.
+	IssuerURL string `json:"issuerURL"`
+	// JWKSURL is fetched and cached to verify token signatures.
+	JWKSURL string `json:"jwksURL"`
+	// Audiences lists the values accepted in the token's "aud" claim.
+	Audiences []string `json:"audiences"`
+
+	// ClaimPolicies maps a claim value (e.g. a "wlcg.groups" entry or a
+	// "scope" token) to a canned policy name already known to the server.
+	ClaimPolicies map[string]string `json:"claimPolicies"`
+}
+
+// Validate checks that the provider is minimally well formed.
+func (p oidcProvider) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+	if p.IssuerURL == "" {
+		return errors.New("oidc: issuerURL cannot be empty")
+	}
+	if p.JWKSURL == "" {
+		return errors.New("oidc: jwksURL cannot be empty")
+	}
+	return nil
+}
+
+// oidcProviders validates the full set of configured providers.
+type oidcProviders map[string]oidcProvider
+
+// Validate validates all configured providers, skipping disabled ones.
+func (providers oidcProviders) Validate() error {
+	for name, p := range providers {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("oidc %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// jwksCache caches fetched JWKS keysets per provider so every request does
+// not round-trip to the issuer.
+type jwksCache struct {
+	mu      sync.RWMutex
+	sets    map[string]*jwk.KeySet
+	fetched map[string]time.Time
+}
+
+var globalJWKSCache = &jwksCache{
+	sets:    make(map[string]*jwk.KeySet),
+	fetched: make(map[string]time.Time),
+}
+
+const jwksCacheTTL = 15 * time.Minute
+
+// keySet returns the cached (or freshly fetched) JWKS for a provider.
+func (c *jwksCache) keySet(name string, provider oidcProvider) (*jwk.KeySet, error) {
+	c.mu.RLock()
+	set, ok := c.sets[name]
+	fetchedAt := c.fetched[name]
+	c.mu.RUnlock()
+
+	if ok && time.Since(fetchedAt) < jwksCacheTTL {
+		return set, nil
+	}
+
+	set, err := jwk.Fetch(provider.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.sets[name] = set
+	c.fetched[name] = time.Now()
+	c.mu.Unlock()
+
+	return set, nil
+}
+
+// setTokenAuthHandler verifies bearer JWTs (OIDC/SciToken) and X.509 proxy
+// client certificates, mapping the resulting claims or certificate subject
+// to a canned bucket policy. A request carrying a recognized, valid token
+// or certificate continues down next with that policy name attached to
+// its context; a request without one continues down next unchanged, so
+// existing SigV4 clients are unaffected. Every request is passed on to
+// next: no handler is skipped, setAuthHandler's SigV4 check included, and
+// the attached policy does not by itself authorize the request.
+func setTokenAuthHandler(next http.Handler) http.Handler {
+	return tokenAuthHandler{next: next}
+}
+
+type tokenAuthHandler struct {
+	next http.Handler
+}
+
+func (t tokenAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if policyName, ok := authenticateRequestToken(r); ok {
+		r = r.WithContext(contextWithCannedPolicy(r.Context(), policyName))
+	}
+	t.next.ServeHTTP(w, r)
+}
+
+// authenticateRequestToken inspects the request for a bearer JWT or an
+// X.509 client certificate and, if present and valid, returns the canned
+// policy name the caller's claims map to.
+func authenticateRequestToken(r *http.Request) (policyName string, ok bool) {
+	if chain := peerCertificateChain(r); len(chain) > 0 {
+		return policyForCertificate(chain)
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", false
+	}
+
+	rawToken := strings.TrimPrefix(authHeader, "Bearer ")
+	return policyForBearerToken(rawToken)
+}
+
+// peerCertificateChain returns the client certificate chain presented
+// during the TLS handshake, leaf first, if any. The gateway's HTTPS
+// listener is configured (see startGateway) to request, but not require,
+// a client certificate, so ordinary SigV4/bearer clients are unaffected.
+func peerCertificateChain(r *http.Request) []*x509.Certificate {
+	if r.TLS == nil {
+		return nil
+	}
+	return r.TLS.PeerCertificates
+}
+
+// policyForCertificate verifies chain (leaf first) against globalRootCAs,
+// the same trusted root pool the gateway's own TLS listener is loaded
+// from, before mapping the verified leaf's subject to a canned policy.
+// Intermediates from the presented chain are fed back into the
+// verification as non-root intermediates so that X.509 proxy certificate
+// chains (RFC 3820), which are typically presented together with their
+// signing end-entity certificate rather than anchored directly in a CA,
+// still verify. A certificate that does not chain to a trusted root is
+// never trusted, regardless of what its subject would otherwise map to.
+func policyForCertificate(chain []*x509.Certificate) (policyName string, ok bool) {
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         globalRootCAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return "", false
+	}
+
+	serverConfigMu.RLock()
+	providers := serverConfig.OIDC
+	serverConfigMu.RUnlock()
+
+	subject := chain[0].Subject.String()
+	for _, provider := range providers {
+		if !provider.Enabled {
+			continue
+		}
+		if name, found := provider.ClaimPolicies[subject]; found {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// policyForBearerToken verifies rawToken against every configured,
+// enabled OIDC provider until one matches, then maps its claims (sub,
+// wlcg.groups, scope) to a canned policy name.
+func policyForBearerToken(rawToken string) (policyName string, ok bool) {
+	serverConfigMu.RLock()
+	providers := serverConfig.OIDC
+	serverConfigMu.RUnlock()
+
+	for name, provider := range providers {
+		if !provider.Enabled {
+			continue
+		}
+
+		claims, err := verifyToken(name, provider, rawToken)
+		if err != nil {
+			continue
+		}
+
+		if policyName, ok = policyFromClaims(provider, claims); ok {
+			return policyName, true
+		}
+	}
+	return "", false
+}
+
+// verifyToken parses and validates rawToken's signature against the
+// provider's JWKS, and checks the issuer and audience claims.
+func verifyToken(name string, provider oidcProvider, rawToken string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		set, err := globalJWKSCache.keySet(name, provider)
+		if err != nil {
+			return nil, err
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		keys := set.Keys
+		if kid != "" {
+			keys = set.Key(kid)
+		}
+		if len(keys) == 0 {
+			return nil, errors.New("oidc: no matching signing key for token")
+		}
+
+		key := keys[0]
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("oidc: unexpected signing method %q, only RSA is trusted for JWKS keys", token.Method.Alg())
+		}
+		if key.Algorithm != "" && token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("oidc: token alg %q does not match JWKS key alg %q", token.Method.Alg(), key.Algorithm)
+		}
+		return key.Key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if iss, _ := claims["iss"].(string); iss != provider.IssuerURL {
+		return nil, errors.New("oidc: unexpected issuer")
+	}
+
+	if !audienceAllowed(claims, provider.Audiences) {
+		return nil, errors.New("oidc: token audience not allowed")
+	}
+
+	return claims, nil
+}
+
+// audienceAllowed reports whether the token's "aud" claim intersects with
+// the provider's configured audience list.
+func audienceAllowed(claims jwt.MapClaims, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	var auds []string
+	switch aud := claims["aud"].(type) {
+	case string:
+		auds = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				auds = append(auds, s)
+			}
+		}
+	}
+
+	for _, a := range auds {
+		for _, want := range allowed {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// policyFromClaims maps a token's "sub", "wlcg.groups" or "scope" claims
+// to a canned policy name, in that precedence order.
+func policyFromClaims(provider oidcProvider, claims jwt.MapClaims) (policyName string, ok bool) {
+	if sub, _ := claims["sub"].(string); sub != "" {
+		if name, found := provider.ClaimPolicies[sub]; found {
+			return name, true
+		}
+	}
+
+	if groups, found := claims["wlcg.groups"].([]interface{}); found {
+		for _, g := range groups {
+			group, _ := g.(string)
+			if name, found := provider.ClaimPolicies[group]; found {
+				return name, true
+			}
+		}
+	}
+
+	if scope, _ := claims["scope"].(string); scope != "" {
+		for _, s := range strings.Fields(scope) {
+			if name, found := provider.ClaimPolicies[s]; found {
+				return name, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 
 	"github.com/minio/minio/pkg/quick"
 	"github.com/tidwall/gjson"
@@ -52,6 +54,15 @@ type serverConfigV19 struct {
 
 	// Notification queue configuration.
 	Notify *notifier `json:"notify"`
+
+	// OIDC holds the set of federated identity issuers bearer tokens are
+	// verified against by setTokenAuthHandler, keyed by an operator
+	// chosen provider name.
+	OIDC oidcProviders `json:"oidc"`
+
+	// Cache configures the bucket-scoped disk cache placed in front of
+	// the gateway's backing GatewayLayer.
+	Cache *cacheConfig `json:"cache"`
 }
 
 // GetVersion get current config version.
@@ -136,6 +147,8 @@ func newServerConfigV19() *serverConfigV19 {
 		Browser:    true,
 		Logger:     &loggers{},
 		Notify:     &notifier{},
+		OIDC:       oidcProviders{},
+		Cache:      &cacheConfig{},
 	}
 
 	// Enable console logger by default on a fresh run.
@@ -160,6 +173,8 @@ func newServerConfigV19() *serverConfigV19 {
 	srvCfg.Notify.Kafka["1"] = kafkaNotify{}
 	srvCfg.Notify.Webhook = make(map[string]webhookNotify)
 	srvCfg.Notify.Webhook["1"] = webhookNotify{}
+	srvCfg.Notify.Pulsar = make(map[string]pulsarNotify)
+	srvCfg.Notify.Pulsar["1"] = pulsarNotify{}
 
 	return srvCfg
 }
@@ -232,6 +247,128 @@ func parseJSON(json string) error {
 	return checkDupJSONKeys(rootKey, config)
 }
 
+// ConfigMigration upgrades an on-disk server configuration found at
+// oldVersion to the next config version in sequence. Implementations are
+// registered with RegisterConfigMigration, keyed by the version they
+// upgrade from, so that adding a new config version only requires adding
+// a new migration rather than growing a hard-coded if/else chain in
+// getValidConfig.
+type ConfigMigration interface {
+	Migrate(oldVersion string) error
+}
+
+// configMigrations holds registered migrations, indexed by the version
+// they upgrade from.
+var configMigrations = map[string]ConfigMigration{}
+
+// RegisterConfigMigration registers a migration to be run against configs
+// found at fromVersion before they are loaded by getValidConfig.
+func RegisterConfigMigration(fromVersion string, m ConfigMigration) {
+	configMigrations[fromVersion] = m
+}
+
+// migrateConfig runs any registered migration chain against the config
+// file on disk until it reaches v19, or returns an error if no migration
+// is registered for the version found.
+func migrateConfig() error {
+	configFile := getConfigFile()
+
+	cv := &configVersion{}
+	if _, err := quick.Load(configFile, cv); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for cv.Version != v19 {
+		m, ok := configMigrations[cv.Version]
+		if !ok {
+			return fmt.Errorf("unable to migrate config version ‘%s’, no migration registered", cv.Version)
+		}
+		if err := m.Migrate(cv.Version); err != nil {
+			return err
+		}
+		if _, err := quick.Load(configFile, cv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configVersion is used to peek at the version field of a config file of
+// unknown version before attempting to load it fully.
+type configVersion struct {
+	Version string `json:"version"`
+}
+
+// notifierV18 mirrors notifier as it existed in config version '18',
+// before the MQTT notification target was added.
+type notifierV18 struct {
+	AMQP          map[string]amqpNotify          `json:"amqp"`
+	ElasticSearch map[string]elasticSearchNotify `json:"elasticsearch"`
+	Redis         map[string]redisNotify         `json:"redis"`
+	NATS          map[string]natsNotify          `json:"nats"`
+	PostgreSQL    map[string]postgreSQLNotify    `json:"postgresql"`
+	MySQL         map[string]mySQLNotify         `json:"mysql"`
+	Kafka         map[string]kafkaNotify         `json:"kafka"`
+	Webhook       map[string]webhookNotify       `json:"webhook"`
+}
+
+// serverConfigV18 is the on-disk shape of config version '18', the last
+// version before MQTT notifications were added.
+type serverConfigV18 struct {
+	Version string `json:"version"`
+
+	Credential credential  `json:"credential"`
+	Region     string      `json:"region"`
+	Browser    BrowserFlag `json:"browser"`
+
+	Logger *loggers     `json:"logger"`
+	Notify *notifierV18 `json:"notify"`
+}
+
+// migrationV18ToV19 upgrades a version '18' configuration to '19' by
+// carrying its fields across unchanged and adding the (empty, by
+// default) MQTT notification target map introduced in '19'.
+type migrationV18ToV19 struct{}
+
+// Migrate implements ConfigMigration.
+func (migrationV18ToV19) Migrate(oldVersion string) error {
+	configFile := getConfigFile()
+
+	cfgV18 := &serverConfigV18{}
+	if _, err := quick.Load(configFile, cfgV18); err != nil {
+		return err
+	}
+
+	cfgV19 := &serverConfigV19{
+		Version:    v19,
+		Credential: cfgV18.Credential,
+		Region:     cfgV18.Region,
+		Browser:    cfgV18.Browser,
+		Logger:     cfgV18.Logger,
+		Notify: &notifier{
+			AMQP:          cfgV18.Notify.AMQP,
+			MQTT:          make(map[string]mqttNotify),
+			ElasticSearch: cfgV18.Notify.ElasticSearch,
+			Redis:         cfgV18.Notify.Redis,
+			NATS:          cfgV18.Notify.NATS,
+			PostgreSQL:    cfgV18.Notify.PostgreSQL,
+			MySQL:         cfgV18.Notify.MySQL,
+			Kafka:         cfgV18.Notify.Kafka,
+			Webhook:       cfgV18.Notify.Webhook,
+		},
+	}
+	cfgV19.Notify.MQTT["1"] = mqttNotify{}
+
+	return quick.Save(configFile, cfgV19)
+}
+
+func init() {
+	RegisterConfigMigration("18", migrationV18ToV19{})
+}
+
 // getValidConfig - returns valid server configuration
 func getValidConfig() (*serverConfigV19, error) {
 	srvCfg := &serverConfigV19{
@@ -239,6 +376,10 @@ func getValidConfig() (*serverConfigV19, error) {
 		Browser: true,
 	}
 
+	if err := migrateConfig(); err != nil {
+		return nil, err
+	}
+
 	configFile := getConfigFile()
 	if _, err := quick.Load(configFile, srvCfg); err != nil {
 		return nil, err
@@ -267,6 +408,16 @@ func getValidConfig() (*serverConfigV19, error) {
 		return nil, err
 	}
 
+	// Validate configured OIDC/federated identity providers.
+	if err = srvCfg.OIDC.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Validate the gateway cache configuration, if present.
+	if err = srvCfg.Cache.Validate(); err != nil {
+		return nil, err
+	}
+
 	return srvCfg, nil
 }
 
@@ -331,3 +482,64 @@ func loadConfig() error {
 
 	return nil
 }
+
+// reloadConfig re-reads the configuration file from disk, validates it and
+// swaps it in under serverConfigMu, refreshing the globals cached from it
+// as well as loggers and enabled Pulsar notifier targets, all without
+// restarting globalHTTPServer. Notifier targets of other kinds are not
+// re-initialized by reloadConfig. It is triggered on receipt of SIGHUP,
+// see handleConfigReload.
+func reloadConfig() error {
+	srvCfg, err := getValidConfig()
+	if err != nil {
+		return err
+	}
+
+	serverConfigMu.Lock()
+	serverConfig = srvCfg
+
+	globalActiveCred = serverConfig.GetCredential()
+	globalIsBrowserEnabled = serverConfig.GetBrowser()
+	globalServerRegion = serverConfig.GetRegion()
+	serverConfigMu.Unlock()
+
+	// Loggers cache config-derived state at init time, re-run their init
+	// routine so the reload takes effect. Pulsar notifier targets are
+	// looked up by newPulsarNotify against whatever serverConfig holds at
+	// call time, reusing the cached client unless the target's
+	// configuration changed, in which case it is re-dialed;
+	// sendConfigReloadEvent below makes that call for every enabled
+	// Pulsar target, publishing a notice so operators watching the event
+	// stream see the reload take effect.
+	enableLoggers()
+
+	sendConfigReloadEvent()
+
+	return nil
+}
+
+// handleConfigReload installs a SIGHUP handler that reloads the server
+// configuration in place, so operators can roll credentials, notifier
+// targets, or other settings without a restart.
+func handleConfigReload() {
+	sighupCh := make(chan os.Signal, 1)
+	signal.Notify(sighupCh, syscall.SIGHUP)
+	go func() {
+		for range sighupCh {
+			if err := reloadConfig(); err != nil {
+				errorIf(err, "Unable to reload configuration on SIGHUP")
+				continue
+			}
+			log.Println("Configuration reloaded from", getConfigFile())
+		}
+	}()
+}
+
+// sendConfigReloadEvent notifies operators, through the enabled Pulsar
+// notifier targets, that the server configuration was reloaded.
+func sendConfigReloadEvent() {
+	if serverConfig == nil || serverConfig.Notify == nil {
+		return
+	}
+	notifyConfigReload(serverConfig.Notify)
+}
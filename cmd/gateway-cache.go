@@ -0,0 +1,484 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cacheConfig describes the on-disk read-through/write-through cache
+// sitting in front of a (typically slow, remote) GatewayLayer.
+type cacheConfig struct {
+	Enable bool `json:"enable"`
+
+	// Drives lists the local disk paths the cache may use. Entries are
+	// spread across drives by a hash of their bucket/object key.
+	Drives []string `json:"drives"`
+
+	// MaxSizeGB bounds the total size, across all drives, the cache is
+	// allowed to grow to before older entries are evicted LRU-first.
+	MaxSizeGB int64 `json:"maxSizeGB"`
+
+	// Exclude lists glob patterns matched against "bucket/object"; a
+	// match is never cached.
+	Exclude []string `json:"exclude"`
+
+	// TTLSeconds bounds how long a cached entry is trusted before it is
+	// revalidated with a conditional HEAD against the backing layer.
+	TTLSeconds int64 `json:"ttlSeconds"`
+}
+
+// Validate checks that an enabled cache config is minimally well formed.
+func (c *cacheConfig) Validate() error {
+	if c == nil || !c.Enable {
+		return nil
+	}
+	if len(c.Drives) == 0 {
+		return errors.New("cache: at least one drive must be configured")
+	}
+	if c.MaxSizeGB <= 0 {
+		return errors.New("cache: maxSizeGB must be greater than zero")
+	}
+	return nil
+}
+
+// ttl returns the configured cache entry TTL, defaulting to one hour.
+func (c *cacheConfig) ttl() time.Duration {
+	if c.TTLSeconds <= 0 {
+		return time.Hour
+	}
+	return time.Duration(c.TTLSeconds) * time.Second
+}
+
+// cacheMaxWriteThroughSize bounds which PutObject calls are mirrored into
+// the cache synchronously; larger objects are left to be populated lazily
+// on first read instead.
+const cacheMaxWriteThroughSize = 1 << 20 // 1 MiB
+
+// cacheMetaSuffix names the sidecar file persisted alongside each cached
+// object file, letting newCacheGatewayLayer rebuild its in-memory index
+// after a restart instead of leaking orphaned cache files.
+const cacheMetaSuffix = ".meta"
+
+var (
+	cacheHitCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "minio",
+		Subsystem: "gateway_cache",
+		Name:      "hits_total",
+		Help:      "Total number of gateway cache hits.",
+	}, []string{"bucket"})
+
+	cacheMissCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "minio",
+		Subsystem: "gateway_cache",
+		Name:      "misses_total",
+		Help:      "Total number of gateway cache misses.",
+	}, []string{"bucket"})
+)
+
+func init() {
+	prometheus.MustRegister(cacheHitCounter)
+	prometheus.MustRegister(cacheMissCounter)
+}
+
+// cacheEntry tracks the on-disk location and validation state of one
+// cached object.
+type cacheEntry struct {
+	path     string
+	size     int64
+	etag     string
+	cachedAt time.Time
+	listElem *list.Element
+}
+
+// cacheEntryMeta is the on-disk sidecar persisted next to each cached
+// object file (at its path plus cacheMetaSuffix), carrying the minimum
+// bookkeeping newCacheGatewayLayer needs to rebuild a cacheEntry for it
+// after a process restart.
+type cacheEntryMeta struct {
+	Key      string    `json:"key"`
+	ETag     string    `json:"etag"`
+	Size     int64     `json:"size"`
+	CachedAt time.Time `json:"cachedAt"`
+}
+
+// cacheObjects wraps a GatewayLayer with a bucket-scoped, LRU evicted disk
+// cache. GETs are served from disk when a fresh, etag-matching entry
+// exists; small PutObjects are mirrored to disk as they're written.
+type cacheObjects struct {
+	GatewayLayer
+
+	cfg *cacheConfig
+
+	mu       sync.Mutex
+	entries  map[string]*cacheEntry // keyed by "bucket/object"
+	lru      *list.List             // front = most recently used
+	curBytes int64
+}
+
+// newCacheGatewayLayer wraps layer with a read-through/write-through disk
+// cache as described by cfg, rebuilding its in-memory index from whatever
+// cache entries a prior process left on cfg.Drives so they keep counting
+// toward MaxSizeGB and keep aging out by TTL/eviction instead of becoming
+// invisible, permanently leaked disk space. Call sites should check
+// cfg.Enable first; newCacheGatewayLayer itself assumes caching is
+// wanted.
+func newCacheGatewayLayer(layer GatewayLayer, cfg *cacheConfig) (GatewayLayer, error) {
+	for _, drive := range cfg.Drives {
+		if err := os.MkdirAll(drive, 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &cacheObjects{
+		GatewayLayer: layer,
+		cfg:          cfg,
+		entries:      make(map[string]*cacheEntry),
+		lru:          list.New(),
+	}
+	c.loadFromDisk()
+
+	return c, nil
+}
+
+// loadFromDisk scans every configured drive for the metadata sidecars
+// written by store, verifies the matching data file is still intact and
+// re-indexes it, ordering entries oldest-to-newest by cachedAt so the
+// rebuilt LRU approximates recency. A sidecar whose data file is missing
+// or the wrong size is treated as orphaned and removed along with it.
+func (c *cacheObjects) loadFromDisk() {
+	type found struct {
+		meta cacheEntryMeta
+		path string
+	}
+	var entries []found
+
+	for _, drive := range c.cfg.Drives {
+		files, err := ioutil.ReadDir(drive)
+		if err != nil {
+			continue
+		}
+
+		for _, f := range files {
+			if !strings.HasSuffix(f.Name(), cacheMetaSuffix) {
+				continue
+			}
+
+			metaPath := filepath.Join(drive, f.Name())
+			dataPath := strings.TrimSuffix(metaPath, cacheMetaSuffix)
+
+			raw, err := ioutil.ReadFile(metaPath)
+			if err != nil {
+				continue
+			}
+			var meta cacheEntryMeta
+			if err := json.Unmarshal(raw, &meta); err != nil {
+				os.Remove(metaPath)
+				continue
+			}
+
+			stat, err := os.Stat(dataPath)
+			if err != nil || stat.Size() != meta.Size {
+				os.Remove(metaPath)
+				os.Remove(dataPath)
+				continue
+			}
+
+			entries = append(entries, found{meta: meta, path: dataPath})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].meta.CachedAt.Before(entries[j].meta.CachedAt)
+	})
+
+	c.mu.Lock()
+	for _, e := range entries {
+		entry := &cacheEntry{
+			path:     e.path,
+			size:     e.meta.Size,
+			etag:     e.meta.ETag,
+			cachedAt: e.meta.CachedAt,
+		}
+		entry.listElem = c.lru.PushFront(e.meta.Key)
+		c.entries[e.meta.Key] = entry
+		c.curBytes += entry.size
+	}
+	c.evictLocked()
+	c.mu.Unlock()
+}
+
+// cacheKey returns the map/eviction key for a (bucket, object) pair.
+func (c *cacheObjects) cacheKey(bucket, object string) string {
+	return pathJoin(bucket, object)
+}
+
+// cachePath maps a cache key to a path on one of the configured drives,
+// spreading entries across drives by hashing the key.
+func (c *cacheObjects) cachePath(key string) string {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+
+	drive := c.cfg.Drives[sum%uint64(len(c.cfg.Drives))]
+	return filepath.Join(drive, fmt.Sprintf("%016x", sum))
+}
+
+// excluded reports whether key matches one of the configured exclude
+// patterns.
+func (c *cacheObjects) excluded(key string) bool {
+	for _, pattern := range c.cfg.Exclude {
+		if ok, _ := filepath.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GetObject serves a GetObject call from the disk cache when a fresh
+// entry is present, without any backend call. Once an entry's TTL has
+// elapsed it is revalidated with a single GetObjectInfo against the
+// backing GatewayLayer: a matching ETag refreshes the entry in place and
+// keeps serving the same disk copy, while a mismatch (or no entry at
+// all) falls through to a full read-through that repopulates the cache
+// for next time.
+func (c *cacheObjects) GetObject(bucket, object string, startOffset, length int64, writer io.Writer) error {
+	key := c.cacheKey(bucket, object)
+	excluded := c.excluded(key)
+
+	if !excluded {
+		if entry, ok := c.freshEntry(key); ok {
+			cacheHitCounter.WithLabelValues(bucket).Inc()
+			return c.serveFromDisk(entry, startOffset, length, writer)
+		}
+	}
+
+	objInfo, err := c.GatewayLayer.GetObjectInfo(bucket, object)
+	if err != nil {
+		return err
+	}
+
+	if !excluded {
+		if entry, ok := c.revalidateEntry(key, objInfo); ok {
+			cacheHitCounter.WithLabelValues(bucket).Inc()
+			return c.serveFromDisk(entry, startOffset, length, writer)
+		}
+	}
+
+	cacheMissCounter.WithLabelValues(bucket).Inc()
+
+	if excluded || startOffset != 0 || length != objInfo.Size || objInfo.Size > c.remainingBytes() {
+		// Partial reads, excluded keys, or objects that can't fit are
+		// always served directly without caching.
+		return c.GatewayLayer.GetObject(bucket, object, startOffset, length, writer)
+	}
+
+	var buf bytes.Buffer
+	if err = c.GatewayLayer.GetObject(bucket, object, 0, objInfo.Size, &buf); err != nil {
+		return err
+	}
+
+	c.store(key, objInfo.ETag, buf.Bytes())
+
+	_, err = writer.Write(buf.Bytes())
+	return err
+}
+
+// freshEntry returns the cache entry for key if one exists and is still
+// within its TTL window, without making any backend call.
+func (c *cacheObjects) freshEntry(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok || time.Since(entry.cachedAt) > c.cfg.ttl() {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	c.lru.MoveToFront(entry.listElem)
+	c.mu.Unlock()
+
+	return entry, true
+}
+
+// revalidateEntry handles an entry whose TTL has elapsed: given objInfo
+// already fetched from the backend by the caller, a matching ETag means
+// the cached bytes are still current, so cachedAt is refreshed and the
+// same disk copy stays in service instead of being re-downloaded.
+func (c *cacheObjects) revalidateEntry(key string, objInfo ObjectInfo) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || entry.etag != objInfo.ETag {
+		return nil, false
+	}
+
+	entry.cachedAt = time.Now()
+	c.lru.MoveToFront(entry.listElem)
+	return entry, true
+}
+
+// serveFromDisk streams the requested range of a cached entry to writer.
+func (c *cacheObjects) serveFromDisk(entry *cacheEntry, startOffset, length int64, writer io.Writer) error {
+	f, err := os.Open(entry.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = f.Seek(startOffset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, io.LimitReader(f, length))
+	return err
+}
+
+// store writes data and its metadata sidecar to disk under key, evicting
+// older entries LRU-first until the configured max cache size is
+// respected.
+func (c *cacheObjects) store(key, etag string, data []byte) {
+	path := c.cachePath(key)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return
+	}
+
+	cachedAt := time.Now()
+	metaBytes, err := json.Marshal(cacheEntryMeta{
+		Key:      key,
+		ETag:     etag,
+		Size:     int64(len(data)),
+		CachedAt: cachedAt,
+	})
+	if err != nil {
+		os.Remove(path)
+		return
+	}
+	if err = ioutil.WriteFile(path+cacheMetaSuffix, metaBytes, 0600); err != nil {
+		os.Remove(path)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.entries[key]; ok {
+		c.lru.Remove(old.listElem)
+		c.curBytes -= old.size
+	}
+
+	entry := &cacheEntry{
+		path:     path,
+		size:     int64(len(data)),
+		etag:     etag,
+		cachedAt: cachedAt,
+	}
+	entry.listElem = c.lru.PushFront(key)
+	c.entries[key] = entry
+	c.curBytes += entry.size
+
+	c.evictLocked()
+}
+
+// evictLocked removes least-recently-used entries until curBytes is back
+// within the configured max cache size. Callers must hold c.mu.
+func (c *cacheObjects) evictLocked() {
+	maxBytes := c.cfg.MaxSizeGB << 30
+	for c.curBytes > maxBytes {
+		back := c.lru.Back()
+		if back == nil {
+			return
+		}
+		key := back.Value.(string)
+		entry := c.entries[key]
+
+		os.Remove(entry.path)
+		os.Remove(entry.path + cacheMetaSuffix)
+		c.curBytes -= entry.size
+		delete(c.entries, key)
+		c.lru.Remove(back)
+	}
+}
+
+// remainingBytes reports how much room is left in the cache budget,
+// used to decide whether an object is worth caching at all.
+func (c *cacheObjects) remainingBytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return (c.cfg.MaxSizeGB << 30) - c.curBytes
+}
+
+// PutObject writes through to the backing GatewayLayer and, for objects
+// no larger than cacheMaxWriteThroughSize, mirrors the write into the cache.
+func (c *cacheObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string, sha256sum string) (ObjectInfo, error) {
+	key := c.cacheKey(bucket, object)
+
+	if c.excluded(key) || size < 0 || size > cacheMaxWriteThroughSize {
+		// size < 0 means the caller doesn't know the length up front
+		// (e.g. a chunked-encoding upload); buffering it via TeeReader
+		// below would be unbounded, so let it through uncached rather
+		// than risk an OOM on an arbitrarily large body.
+		return c.GatewayLayer.PutObject(bucket, object, size, data, metadata, sha256sum)
+	}
+
+	var buf bytes.Buffer
+	objInfo, err := c.GatewayLayer.PutObject(bucket, object, size, io.TeeReader(data, &buf), metadata, sha256sum)
+	if err != nil {
+		return objInfo, err
+	}
+
+	c.store(key, objInfo.ETag, buf.Bytes())
+	return objInfo, nil
+}
+
+// DeleteObject invalidates any cached entry before deleting upstream.
+func (c *cacheObjects) DeleteObject(bucket, object string) error {
+	c.invalidate(c.cacheKey(bucket, object))
+	return c.GatewayLayer.DeleteObject(bucket, object)
+}
+
+// invalidate removes a cached entry, if any, from disk and bookkeeping.
+func (c *cacheObjects) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	os.Remove(entry.path)
+	os.Remove(entry.path + cacheMetaSuffix)
+	c.curBytes -= entry.size
+	delete(c.entries, key)
+	c.lru.Remove(entry.listElem)
+}
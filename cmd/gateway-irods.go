@@ -0,0 +1,677 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyverse/go-irodsclient/irods/connection"
+	"github.com/cyverse/go-irodsclient/irods/fs"
+	"github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/minio/cli"
+	"github.com/minio/minio-go/pkg/policy"
+)
+
+const (
+	irodsBackend = "irods"
+
+	// irodsTmpCollectionSuffix is the collection suffix multipart upload
+	// parts are staged under until the upload is completed or aborted.
+	irodsTmpCollectionSuffix = ".minio.tmp"
+
+	// irodsConnTimeout bounds how long we wait to establish a control
+	// connection to the iRODS server.
+	irodsConnTimeout = 30 * time.Second
+
+	// irodsMaxListKeys bounds how many entries ListObjects returns per
+	// call when the caller didn't ask for a smaller page, matching the
+	// S3 default max-keys.
+	irodsMaxListKeys = 1000
+)
+
+var irodsGatewayTemplate = `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} {{if .VisibleFlags}}[FLAGS]{{end}} ZONE
+
+ZONE:
+  iRODS zone name, e.g. tempZone
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+ENVIRONMENT VARIABLES:
+  ACCESS:
+     MINIO_ACCESS_KEY: Username or access key of iRODS storage.
+     MINIO_SECRET_KEY: Password or secret key of iRODS storage.
+
+  BROWSER:
+     MINIO_BROWSER: To disable web browser access, set this value to "off".
+
+  IRODS:
+     MINIO_IRODS_HOST: iRODS server host, defaults to "localhost".
+     MINIO_IRODS_PORT: iRODS server port, defaults to "1247".
+     MINIO_IRODS_AUTH_SCHEME: One of "native", "pam" or "anonymous", defaults to "native".
+     MINIO_IRODS_ZONE: Default zone used when none is given on the command line.
+
+EXAMPLES:
+  1. Start minio gateway server for iRODS backend.
+     $ export MINIO_ACCESS_KEY=rods
+     $ export MINIO_SECRET_KEY=rods
+     $ {{.HelpName}} tempZone
+`
+
+func init() {
+	MustRegisterGatewayCommand(cli.Command{
+		Name:               irodsBackend,
+		Usage:              "iRODS Data Storage.",
+		Action:             irodsGatewayMain,
+		CustomHelpTemplate: irodsGatewayTemplate,
+		Flags:              append(serverFlags, globalFlags...),
+		HideHelpCommand:    true,
+	})
+}
+
+// Handler for 'minio gateway irods' command line.
+func irodsGatewayMain(ctx *cli.Context) {
+	zone := ctx.Args().First()
+	if zone == "" {
+		zone = os.Getenv("MINIO_IRODS_ZONE")
+	}
+	if zone == "" && ctx.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(ctx, irodsBackend, 1)
+	}
+
+	startGateway(ctx, &IRODSGateway{zone: zone})
+}
+
+// IRODSGateway implements Gateway, backing an S3 bucket namespace with an
+// iRODS zone.
+type IRODSGateway struct {
+	zone string
+}
+
+// Name implements Gateway interface.
+func (g *IRODSGateway) Name() string {
+	return irodsBackend
+}
+
+// NewGatewayLayer returns a new iRODS GatewayLayer.
+func (g *IRODSGateway) NewGatewayLayer() (GatewayLayer, error) {
+	host := os.Getenv("MINIO_IRODS_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+
+	port := 1247
+	if p := os.Getenv("MINIO_IRODS_PORT"); p != "" {
+		parsed, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MINIO_IRODS_PORT %q: %v", p, err)
+		}
+		port = parsed
+	}
+
+	scheme := authSchemeFromString(os.Getenv("MINIO_IRODS_AUTH_SCHEME"))
+
+	account, err := types.CreateIRODSAccount(host, port, globalActiveCred.AccessKey,
+		globalActiveCred.SecretKey, g.zone, scheme, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	pool := newIRODSConnPool(account)
+
+	// Dial once up front so a misconfigured zone/credential is caught at
+	// startup rather than on the first incoming request.
+	conn, err := pool.get()
+	if err != nil {
+		return nil, err
+	}
+	pool.put(conn)
+
+	return &irodsObjects{
+		zone: g.zone,
+		pool: pool,
+	}, nil
+}
+
+// authSchemeFromString maps the MINIO_IRODS_AUTH_SCHEME env var to the
+// go-irodsclient auth scheme, defaulting to native password auth.
+func authSchemeFromString(scheme string) types.AuthScheme {
+	switch strings.ToLower(scheme) {
+	case "pam":
+		return types.AuthSchemePAM
+	case "anonymous":
+		return types.AuthSchemeAnonymous
+	default:
+		return types.AuthSchemeNative
+	}
+}
+
+// irodsConnPool checks out one *connection.IRODSConnection per call and
+// takes it back afterwards. go-irodsclient connections are single-session
+// and not safe for concurrent use, but irodsObjects methods are invoked
+// concurrently by every in-flight HTTP request the gateway serves, so a
+// single shared connection isn't safe here.
+type irodsConnPool struct {
+	account *types.IRODSAccount
+
+	mu   sync.Mutex
+	idle []*connection.IRODSConnection
+}
+
+// newIRODSConnPool returns a pool that dials new connections against
+// account as needed.
+func newIRODSConnPool(account *types.IRODSAccount) *irodsConnPool {
+	return &irodsConnPool{account: account}
+}
+
+// get returns an idle connection if one is available, otherwise dials and
+// connects a new one.
+func (p *irodsConnPool) get() (*connection.IRODSConnection, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		conn := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return conn, nil
+	}
+	p.mu.Unlock()
+
+	conn, err := connection.NewIRODSConnection(p.account, irodsConnTimeout, "minio-gateway")
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.Connect(); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// put returns conn to the pool for a later caller to reuse.
+func (p *irodsConnPool) put(conn *connection.IRODSConnection) {
+	p.mu.Lock()
+	p.idle = append(p.idle, conn)
+	p.mu.Unlock()
+}
+
+// closeAll disconnects every idle connection in the pool.
+func (p *irodsConnPool) closeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.idle {
+		conn.Disconnect()
+	}
+	p.idle = nil
+}
+
+// irodsObjects implements GatewayLayer against an iRODS zone, mapping S3
+// buckets to top-level collections and S3 objects to iRODS data objects.
+type irodsObjects struct {
+	zone string
+	pool *irodsConnPool
+}
+
+// irodsPath returns the absolute iRODS collection path for an S3 bucket.
+func (l *irodsObjects) irodsPath(bucket string) string {
+	return fmt.Sprintf("/%s/home/%s", l.zone, bucket)
+}
+
+// irodsObjectPath returns the absolute iRODS data object path for an
+// S3 (bucket, object) pair.
+func (l *irodsObjects) irodsObjectPath(bucket, object string) string {
+	return pathJoin(l.irodsPath(bucket), object)
+}
+
+// Shutdown saves any gateway layer state before exiting.
+func (l *irodsObjects) Shutdown() error {
+	l.pool.closeAll()
+	return nil
+}
+
+// StorageInfo is not relevant for iRODS backend.
+func (l *irodsObjects) StorageInfo() StorageInfo {
+	return StorageInfo{}
+}
+
+// MakeBucket creates a new collection under the zone's home namespace.
+func (l *irodsObjects) MakeBucket(bucket string) error {
+	conn, err := l.pool.get()
+	if err != nil {
+		return irodsToObjectErr(err, bucket)
+	}
+	defer l.pool.put(conn)
+
+	return fs.CreateCollection(conn, l.irodsPath(bucket), true)
+}
+
+// GetBucketInfo gets collection metadata and translates it to BucketInfo.
+func (l *irodsObjects) GetBucketInfo(bucket string) (bi BucketInfo, err error) {
+	conn, err := l.pool.get()
+	if err != nil {
+		return bi, irodsToObjectErr(err, bucket)
+	}
+	defer l.pool.put(conn)
+
+	coll, err := fs.GetCollection(conn, l.irodsPath(bucket))
+	if err != nil {
+		return bi, irodsToObjectErr(err, bucket)
+	}
+	return BucketInfo{
+		Name:    bucket,
+		Created: coll.CreateTime,
+	}, nil
+}
+
+// ListBuckets lists the collections directly under the zone's home namespace.
+func (l *irodsObjects) ListBuckets() ([]BucketInfo, error) {
+	conn, err := l.pool.get()
+	if err != nil {
+		return nil, irodsToObjectErr(err)
+	}
+	defer l.pool.put(conn)
+
+	colls, err := fs.ListCollectionsUnderPath(conn, fmt.Sprintf("/%s/home", l.zone))
+	if err != nil {
+		return nil, irodsToObjectErr(err)
+	}
+
+	var buckets []BucketInfo
+	for _, coll := range colls {
+		buckets = append(buckets, BucketInfo{
+			Name:    coll.Name,
+			Created: coll.CreateTime,
+		})
+	}
+	return buckets, nil
+}
+
+// DeleteBucket deletes a collection, recursively removing its contents.
+func (l *irodsObjects) DeleteBucket(bucket string) error {
+	conn, err := l.pool.get()
+	if err != nil {
+		return irodsToObjectErr(err, bucket)
+	}
+	defer l.pool.put(conn)
+
+	err = fs.RemoveCollection(conn, l.irodsPath(bucket), true)
+	return irodsToObjectErr(err, bucket)
+}
+
+// ListObjects lists data objects under a collection, translating entries
+// to ObjectInfo including the iRODS checksum as ETag. Entries are sorted
+// by path so that marker resumes a listing at the right place; when
+// delimiter is set, entries sharing a path segment past prefix are folded
+// into a single common prefix instead of being listed individually, and
+// at most maxKeys objects+prefixes are returned per call, with
+// IsTruncated/NextMarker set so the caller can page through the rest.
+func (l *irodsObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (loi ListObjectsInfo, err error) {
+	conn, err := l.pool.get()
+	if err != nil {
+		return loi, irodsToObjectErr(err, bucket, prefix)
+	}
+	defer l.pool.put(conn)
+
+	entries, err := fs.ListDataObjectsUnderPath(conn, l.irodsObjectPath(bucket, prefix))
+	if err != nil {
+		return loi, irodsToObjectErr(err, bucket, prefix)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	if maxKeys <= 0 || maxKeys > irodsMaxListKeys {
+		maxKeys = irodsMaxListKeys
+	}
+
+	// entry.Path is the absolute iRODS path (/zone/home/bucket/...);
+	// everything else here (ObjectInfo.Name, loi.Prefixes, NextMarker,
+	// and the marker/delimiter comparisons) works in terms of the
+	// bucket-relative key, matching GetObjectInfo/GetObject.
+	bucketPath := l.irodsPath(bucket) + "/"
+
+	seenPrefixes := make(map[string]bool)
+	for _, entry := range entries {
+		key := strings.TrimPrefix(entry.Path, bucketPath)
+		if key <= marker {
+			continue
+		}
+
+		isCommonPrefix := false
+		if delimiter != "" {
+			if idx := strings.Index(strings.TrimPrefix(key, prefix), delimiter); idx >= 0 {
+				key = key[:len(prefix)+idx+len(delimiter)]
+				isCommonPrefix = true
+			}
+		}
+
+		if isCommonPrefix && seenPrefixes[key] {
+			continue
+		}
+
+		if len(loi.Objects)+len(loi.Prefixes) >= maxKeys {
+			loi.IsTruncated = true
+			loi.NextMarker = key
+			return loi, nil
+		}
+
+		if isCommonPrefix {
+			seenPrefixes[key] = true
+			loi.Prefixes = append(loi.Prefixes, key)
+			continue
+		}
+
+		loi.Objects = append(loi.Objects, ObjectInfo{
+			Bucket:  bucket,
+			Name:    key,
+			ModTime: entry.ModifyTime,
+			Size:    entry.Size,
+			ETag:    irodsChecksumToETag(entry.CheckSum),
+		})
+	}
+	return loi, nil
+}
+
+// GetObjectInfo reads back a data object's metadata and checksum.
+func (l *irodsObjects) GetObjectInfo(bucket, object string) (objInfo ObjectInfo, err error) {
+	conn, err := l.pool.get()
+	if err != nil {
+		return objInfo, irodsToObjectErr(err, bucket, object)
+	}
+	defer l.pool.put(conn)
+
+	obj, err := fs.Stat(conn, l.irodsObjectPath(bucket, object))
+	if err != nil {
+		return objInfo, irodsToObjectErr(err, bucket, object)
+	}
+	return ObjectInfo{
+		Bucket:  bucket,
+		Name:    object,
+		ModTime: obj.ModifyTime,
+		Size:    obj.Size,
+		ETag:    irodsChecksumToETag(obj.CheckSum),
+	}, nil
+}
+
+// GetObject reads the requested byte range of a data object via the
+// iRODS protocol client and writes it to writer.
+func (l *irodsObjects) GetObject(bucket, object string, startOffset, length int64, writer io.Writer) error {
+	conn, err := l.pool.get()
+	if err != nil {
+		return irodsToObjectErr(err, bucket, object)
+	}
+	defer l.pool.put(conn)
+
+	handle, err := fs.OpenDataObject(conn, l.irodsObjectPath(bucket, object), types.FileOpenModeReadOnly)
+	if err != nil {
+		return irodsToObjectErr(err, bucket, object)
+	}
+	defer handle.Close()
+
+	if _, err = handle.Seek(startOffset, io.SeekStart); err != nil {
+		return irodsToObjectErr(err, bucket, object)
+	}
+
+	_, err = io.Copy(writer, io.LimitReader(handle, length))
+	return irodsToObjectErr(err, bucket, object)
+}
+
+// PutObject streams data into a new data object, computing MD5 and SHA256
+// so the resulting ETag can be validated against the client-supplied one.
+func (l *irodsObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string, sha256sum string) (objInfo ObjectInfo, err error) {
+	conn, err := l.pool.get()
+	if err != nil {
+		return objInfo, irodsToObjectErr(err, bucket, object)
+	}
+	defer l.pool.put(conn)
+
+	md5Hasher := md5.New()
+	sha256Hasher := sha256.New()
+	teeReader := io.TeeReader(data, io.MultiWriter(md5Hasher, sha256Hasher))
+
+	handle, err := fs.CreateDataObject(conn, l.irodsObjectPath(bucket, object), true)
+	if err != nil {
+		return objInfo, irodsToObjectErr(err, bucket, object)
+	}
+	defer handle.Close()
+
+	if _, err = io.Copy(handle, teeReader); err != nil {
+		return objInfo, irodsToObjectErr(err, bucket, object)
+	}
+
+	if sha256sum != "" && hex.EncodeToString(sha256Hasher.Sum(nil)) != sha256sum {
+		return objInfo, errSHA256Mismatch
+	}
+
+	return ObjectInfo{
+		Bucket: bucket,
+		Name:   object,
+		Size:   size,
+		ETag:   hex.EncodeToString(md5Hasher.Sum(nil)),
+	}, nil
+}
+
+// CopyObject copies a data object server-side using an iRODS rename/copy.
+func (l *irodsObjects) CopyObject(srcBucket, srcObject, destBucket, destObject string, metadata map[string]string) (objInfo ObjectInfo, err error) {
+	conn, err := l.pool.get()
+	if err != nil {
+		return objInfo, irodsToObjectErr(err, srcBucket, srcObject)
+	}
+
+	err = fs.CopyDataObject(conn, l.irodsObjectPath(srcBucket, srcObject), l.irodsObjectPath(destBucket, destObject))
+	l.pool.put(conn)
+	if err != nil {
+		return objInfo, irodsToObjectErr(err, srcBucket, srcObject)
+	}
+	return l.GetObjectInfo(destBucket, destObject)
+}
+
+// DeleteObject removes a data object.
+func (l *irodsObjects) DeleteObject(bucket, object string) error {
+	conn, err := l.pool.get()
+	if err != nil {
+		return irodsToObjectErr(err, bucket, object)
+	}
+	defer l.pool.put(conn)
+
+	err = fs.RemoveDataObject(conn, l.irodsObjectPath(bucket, object), true)
+	return irodsToObjectErr(err, bucket, object)
+}
+
+// NewMultipartUpload creates the temporary collection the upload's parts
+// are staged under until CompleteMultipartUpload or AbortMultipartUpload.
+func (l *irodsObjects) NewMultipartUpload(bucket, object string, metadata map[string]string) (uploadID string, err error) {
+	conn, err := l.pool.get()
+	if err != nil {
+		return "", irodsToObjectErr(err, bucket, object)
+	}
+	defer l.pool.put(conn)
+
+	uploadID = mustGetUUID()
+	tmpColl := l.tmpUploadCollection(bucket, object, uploadID)
+	if err = fs.CreateCollection(conn, tmpColl, true); err != nil {
+		return "", irodsToObjectErr(err, bucket, object)
+	}
+	return uploadID, nil
+}
+
+// tmpUploadCollection returns the staging collection path for a given
+// multipart upload.
+func (l *irodsObjects) tmpUploadCollection(bucket, object, uploadID string) string {
+	return fmt.Sprintf("%s%s/%s", l.irodsObjectPath(bucket, object), irodsTmpCollectionSuffix, uploadID)
+}
+
+// PutObjectPart writes a staged part as its own data object under the
+// upload's temporary collection.
+func (l *irodsObjects) PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string, sha256sum string) (pi PartInfo, err error) {
+	conn, err := l.pool.get()
+	if err != nil {
+		return pi, irodsToObjectErr(err, bucket, object)
+	}
+	defer l.pool.put(conn)
+
+	partPath := fmt.Sprintf("%s/%05d", l.tmpUploadCollection(bucket, object, uploadID), partID)
+
+	handle, err := fs.CreateDataObject(conn, partPath, true)
+	if err != nil {
+		return pi, irodsToObjectErr(err, bucket, object)
+	}
+	defer handle.Close()
+
+	hasher := md5.New()
+	if _, err = io.Copy(handle, io.TeeReader(data, hasher)); err != nil {
+		return pi, irodsToObjectErr(err, bucket, object)
+	}
+
+	etag := hex.EncodeToString(hasher.Sum(nil))
+	if md5Hex != "" && etag != md5Hex {
+		return pi, errMD5Mismatch
+	}
+
+	return PartInfo{
+		PartNumber: partID,
+		Size:       size,
+		ETag:       etag,
+	}, nil
+}
+
+// CompleteMultipartUpload concatenates the staged parts directly into the
+// final data object, then removes the temporary collection. The final
+// object is written in place, not renamed into place, so the completion
+// is not atomic: a failure part way leaves a partially written object.
+func (l *irodsObjects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []CompletePart) (objInfo ObjectInfo, err error) {
+	conn, err := l.pool.get()
+	if err != nil {
+		return objInfo, irodsToObjectErr(err, bucket, object)
+	}
+	defer l.pool.put(conn)
+
+	tmpColl := l.tmpUploadCollection(bucket, object, uploadID)
+	finalPath := l.irodsObjectPath(bucket, object)
+
+	handle, err := fs.CreateDataObject(conn, finalPath, true)
+	if err != nil {
+		return objInfo, irodsToObjectErr(err, bucket, object)
+	}
+
+	for _, part := range uploadedParts {
+		partPath := fmt.Sprintf("%s/%05d", tmpColl, part.PartNumber)
+		partHandle, perr := fs.OpenDataObject(conn, partPath, types.FileOpenModeReadOnly)
+		if perr != nil {
+			handle.Close()
+			return objInfo, irodsToObjectErr(perr, bucket, object)
+		}
+		_, err = io.Copy(handle, partHandle)
+		partHandle.Close()
+		if err != nil {
+			handle.Close()
+			return objInfo, irodsToObjectErr(err, bucket, object)
+		}
+	}
+	handle.Close()
+
+	if err = fs.RemoveCollection(conn, tmpColl, true); err != nil {
+		return objInfo, irodsToObjectErr(err, bucket, object)
+	}
+
+	return l.GetObjectInfo(bucket, object)
+}
+
+// AbortMultipartUpload removes the temporary staging collection.
+func (l *irodsObjects) AbortMultipartUpload(bucket, object, uploadID string) error {
+	conn, err := l.pool.get()
+	if err != nil {
+		return irodsToObjectErr(err, bucket, object)
+	}
+	defer l.pool.put(conn)
+
+	err = fs.RemoveCollection(conn, l.tmpUploadCollection(bucket, object, uploadID), true)
+	return irodsToObjectErr(err, bucket, object)
+}
+
+// ListObjectParts is not supported, staged parts are tracked internally
+// only for the lifetime of the upload.
+func (l *irodsObjects) ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (lpi ListPartsInfo, err error) {
+	return lpi, traceError(NotImplemented{})
+}
+
+// CopyObjectPart is not supported by the iRODS gateway.
+func (l *irodsObjects) CopyObjectPart(srcBucket, srcObject, destBucket, destObject, uploadID string, partID int, startOffset, length int64) (pi PartInfo, err error) {
+	return pi, traceError(NotImplemented{})
+}
+
+// SetBucketPolicies is not supported, bucket policy is not modeled in iRODS ACLs yet.
+func (l *irodsObjects) SetBucketPolicies(bucket string, policyInfo policy.BucketAccessPolicy) error {
+	return traceError(NotImplemented{})
+}
+
+// GetBucketPolicies is not supported; it returns an empty bucket policy
+// together with a NotImplemented error.
+func (l *irodsObjects) GetBucketPolicies(bucket string) (policy.BucketAccessPolicy, error) {
+	return policy.BucketAccessPolicy{}, traceError(NotImplemented{})
+}
+
+// DeleteBucketPolicies is not supported by the iRODS gateway.
+func (l *irodsObjects) DeleteBucketPolicies(bucket string) error {
+	return traceError(NotImplemented{})
+}
+
+// irodsChecksumToETag normalizes an iRODS checksum (MD5 or SHA256, with an
+// optional "sha2:" prefix) into the hex form used for S3 ETags.
+func irodsChecksumToETag(checksum string) string {
+	return strings.TrimPrefix(checksum, "sha2:")
+}
+
+// irodsToObjectErr translates go-irodsclient errors to the gateway's
+// object-layer error types.
+func irodsToObjectErr(err error, params ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	bucket := ""
+	object := ""
+	if len(params) >= 1 {
+		bucket = params[0]
+	}
+	if len(params) >= 2 {
+		object = params[1]
+	}
+
+	switch {
+	case types.IsCollectionNotExistError(err), types.IsDataObjectNotExistError(err):
+		if object != "" {
+			return traceError(ObjectNotFound{Bucket: bucket, Object: object})
+		}
+		return traceError(BucketNotFound{Bucket: bucket})
+	case types.IsCollectionAlreadyExistError(err):
+		return traceError(BucketAlreadyExists{Bucket: bucket})
+	default:
+		return traceError(err)
+	}
+}
@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/url"
@@ -173,6 +174,17 @@ func startGateway(ctx *cli.Context, gw Gateway) {
 	newObject, err := gw.NewGatewayLayer()
 	fatalIf(err, "Unable to initialize gateway layer")
 
+	// Wrap the gateway layer with a bucket-scoped disk cache when one is
+	// configured, so repeat GETs against the (often slow, remote)
+	// backend are served from local disk instead.
+	serverConfigMu.RLock()
+	cacheCfg := serverConfig.Cache
+	serverConfigMu.RUnlock()
+	if cacheCfg != nil && cacheCfg.Enable {
+		newObject, err = newCacheGatewayLayer(newObject, cacheCfg)
+		fatalIf(err, "Unable to initialize gateway cache")
+	}
+
 	router := mux.NewRouter().SkipClean(true)
 
 	// Register web router when its enabled.
@@ -202,6 +214,12 @@ func startGateway(ctx *cli.Context, gw Gateway) {
 		// Validates all incoming URL resources, for invalid/unsupported
 		// resources client receives a HTTP error.
 		setIgnoreResourcesHandler,
+		// Verifies bearer JWTs (OIDC/SciToken) and X.509 proxy certs
+		// against the configured federated identity issuers, mapping
+		// claims to a canned policy attached to the request's context.
+		// Every request continues down the same handler chain, and
+		// setAuthHandler's SigV4 check is not skipped for any of them.
+		setTokenAuthHandler,
 		// Auth handler verifies incoming authorization headers and
 		// routes them accordingly. Client receives a HTTP error for
 		// invalid/unsupported signatures.
@@ -211,6 +229,15 @@ func startGateway(ctx *cli.Context, gw Gateway) {
 
 	globalHTTPServer = miniohttp.NewServer([]string{gatewayAddr}, registerHandlers(router, handlerFns...), globalTLSCertificate)
 
+	// Requesting (but not requiring) a client certificate lets
+	// setTokenAuthHandler authenticate X.509 proxy certs over the same
+	// listener ordinary SigV4/bearer clients use, verified against the
+	// same trusted root pool loaded for the listener's own certificate.
+	if globalIsSSL && globalHTTPServer.TLSConfig != nil {
+		globalHTTPServer.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		globalHTTPServer.TLSConfig.ClientCAs = globalRootCAs
+	}
+
 	// Start server, automatically configures TLS if certs are available.
 	go func() {
 		globalHTTPServerErrorCh <- globalHTTPServer.Start()
@@ -218,6 +245,10 @@ func startGateway(ctx *cli.Context, gw Gateway) {
 
 	signal.Notify(globalOSSignalCh, os.Interrupt, syscall.SIGTERM)
 
+	// Reload configuration in place on SIGHUP, instead of requiring a
+	// restart of the gateway process.
+	handleConfigReload()
+
 	// Once endpoints are finalized, initialize the new object api.
 	globalObjLayerMutex.Lock()
 	globalObjectAPI = newObject
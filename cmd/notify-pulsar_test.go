@@ -0,0 +1,120 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+// mockPulsarProducer is an in-memory stand-in for a pulsar.Producer,
+// acting as a mock broker: Send appends to sent instead of talking to a
+// real Pulsar service.
+type mockPulsarProducer struct {
+	topic string
+	sent  []*pulsar.ProducerMessage
+	err   error
+}
+
+func (m *mockPulsarProducer) Topic() string { return m.topic }
+func (m *mockPulsarProducer) Name() string  { return "mock" }
+
+func (m *mockPulsarProducer) Send(ctx context.Context, msg *pulsar.ProducerMessage) (pulsar.MessageID, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil, nil
+}
+
+func (m *mockPulsarProducer) SendAsync(ctx context.Context, msg *pulsar.ProducerMessage, cb func(pulsar.MessageID, *pulsar.ProducerMessage, error)) {
+	_, err := m.Send(ctx, msg)
+	if cb != nil {
+		cb(nil, msg, err)
+	}
+}
+
+func (m *mockPulsarProducer) LastSequenceID() int64 { return int64(len(m.sent)) }
+func (m *mockPulsarProducer) Flush() error           { return nil }
+func (m *mockPulsarProducer) Close()                 {}
+
+func TestPulsarTopicName(t *testing.T) {
+	p := pulsarNotify{Tenant: "sara-nl", Namespace: "minio", Topic: "events"}
+	want := "persistent://sara-nl/minio/events"
+	if got := pulsarTopicName(p); got != want {
+		t.Fatalf("pulsarTopicName() = %q, want %q", got, want)
+	}
+}
+
+func TestPulsarNotifyValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		notify  pulsarNotify
+		wantErr bool
+	}{
+		{"disabled is always valid", pulsarNotify{Enable: false}, false},
+		{"missing address", pulsarNotify{Enable: true, Tenant: "t", Namespace: "n", Topic: "x"}, true},
+		{"missing tenant", pulsarNotify{Enable: true, Address: "pulsar://localhost:6650", Namespace: "n", Topic: "x"}, true},
+		{"missing namespace", pulsarNotify{Enable: true, Address: "pulsar://localhost:6650", Tenant: "t", Topic: "x"}, true},
+		{"missing topic", pulsarNotify{Enable: true, Address: "pulsar://localhost:6650", Tenant: "t", Namespace: "n"}, true},
+		{"fully configured", pulsarNotify{Enable: true, Address: "pulsar://localhost:6650", Tenant: "t", Namespace: "n", Topic: "x"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.notify.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPulsarLoggerFire(t *testing.T) {
+	mock := &mockPulsarProducer{topic: "persistent://t/n/x"}
+	target := pulsarLogger{Enable: true, Topic: mock.topic, producer: mock}
+
+	log := logrus.New()
+	log.Hooks.Add(target)
+	log.Info("s3:ObjectCreated:Put")
+
+	if len(mock.sent) != 1 {
+		t.Fatalf("expected 1 message published to the mock broker, got %d", len(mock.sent))
+	}
+}
+
+func TestPulsarLoggerFirePropagatesProducerError(t *testing.T) {
+	mock := &mockPulsarProducer{topic: "persistent://t/n/x", err: errors.New("broker unavailable")}
+	target := pulsarLogger{Enable: true, Topic: mock.topic, producer: mock}
+
+	entry := &logrus.Entry{Logger: logrus.New(), Message: "s3:ObjectCreated:Put"}
+	if err := target.Fire(entry); err == nil {
+		t.Fatal("expected Fire to propagate the mock broker's error")
+	}
+}
+
+func TestPulsarLoggerLevels(t *testing.T) {
+	target := pulsarLogger{}
+	levels := target.Levels()
+	if len(levels) != 1 || levels[0] != logrus.InfoLevel {
+		t.Fatalf("Levels() = %v, want [InfoLevel]", levels)
+	}
+}